@@ -0,0 +1,44 @@
+// Package acr provides an auth.CredentialProvider backed by Azure AD
+// workload identity, for pulling from *.azurecr.io registries without a
+// dockercfg entry.
+package acr
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/openshift/image-inspector/pkg/auth"
+)
+
+func init() {
+	auth.RegisterProvider(&Provider{})
+}
+
+// Provider resolves ACR registry refresh tokens via Azure AD.
+type Provider struct{}
+
+// Name implements auth.CredentialProvider.
+func (p *Provider) Name() string {
+	return "acr"
+}
+
+// Provide implements auth.CredentialProvider. It only handles ACR
+// hostnames; any other registry is left for the next provider in the
+// chain.
+func (p *Provider) Provide(registry string) (auth.Credentials, bool, error) {
+	if !strings.HasSuffix(registry, ".azurecr.io") {
+		return auth.Credentials{}, false, nil
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return auth.Credentials{}, true, err
+	}
+	token, err := cred.GetToken(context.Background(), policyTokenOptions())
+	if err != nil {
+		return auth.Credentials{}, true, err
+	}
+	return auth.Credentials{Username: "00000000-0000-0000-0000-000000000000", Password: token.Token}, true, nil
+}
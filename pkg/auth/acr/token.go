@@ -0,0 +1,9 @@
+package acr
+
+import "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+func policyTokenOptions() policy.TokenRequestOptions {
+	return policy.TokenRequestOptions{
+		Scopes: []string{"https://management.azure.com/.default"},
+	}
+}
@@ -0,0 +1,139 @@
+// Package auth resolves per-registry pull credentials the way kubelet's
+// credentialprovider does: merging dockercfg/config.json files by
+// registry hostname, shelling out to docker-credential-* helpers for
+// credHelpers/credsStore entries, and consulting cloud credential
+// providers (ECR, GCR, ACR) when none of those apply. Each registry
+// hostname is resolved on its own, instead of through the single
+// dockercfg-or-username/password pair ImageInspectorOptions used to
+// support.
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Credentials holds the resolved username/password for a single registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// dockerConfigEntry mirrors a single "auths" entry in a dockercfg/
+// config.json file.
+type dockerConfigEntry struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigEntry `json:"auths"`
+	CredHelpers map[string]string            `json:"credHelpers"`
+	CredsStore  string                       `json:"credsStore"`
+}
+
+// Resolver merges one or more dockercfg/config.json files and consults
+// credential helpers and cloud credential providers to answer, for a
+// given registry hostname, which credentials to pull with.
+type Resolver struct {
+	entries     map[string]dockerConfigEntry
+	credHelpers map[string]string
+	credsStore  string
+	// Providers are cloud credential providers to fall back to (in
+	// order) when no dockercfg entry or credential helper matches a
+	// registry, selected via --credential-provider.
+	Providers []CredentialProvider
+}
+
+// CredentialProvider resolves credentials for a registry the way a cloud
+// provider's image pull secret would, e.g. fetching a short-lived ECR
+// authorization token. Built-in providers are registered in
+// pkg/auth/ecr, pkg/auth/gcr and pkg/auth/acr.
+type CredentialProvider interface {
+	// Name is the value used on --credential-provider to select this
+	// provider, e.g. "ecr".
+	Name() string
+	// Provide returns credentials for registry, or ok=false if this
+	// provider does not handle it.
+	Provide(registry string) (creds Credentials, ok bool, err error)
+}
+
+// NewResolver builds a Resolver by merging the dockercfg/config.json
+// files at paths, in order, with later files taking precedence for a
+// given registry hostname.
+func NewResolver(paths []string) (*Resolver, error) {
+	r := &Resolver{
+		entries:     map[string]dockerConfigEntry{},
+		credHelpers: map[string]string{},
+	}
+	for _, p := range paths {
+		if err := r.merge(p); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", p, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Resolver) merge(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return fmt.Errorf("invalid docker config json: %v", err)
+	}
+	for registry, entry := range cfg.Auths {
+		r.entries[registry] = entry
+	}
+	for registry, helper := range cfg.CredHelpers {
+		r.credHelpers[registry] = helper
+	}
+	if len(cfg.CredsStore) > 0 {
+		r.credsStore = cfg.CredsStore
+	}
+	return nil
+}
+
+// Resolve returns the credentials image-inspector should use to pull
+// from registry, trying, in order: a matching credHelpers entry, the
+// global credsStore, a merged dockercfg "auths" entry, then each
+// cloud CredentialProvider in r.Providers.
+func (r *Resolver) Resolve(registry string) (Credentials, error) {
+	if helper, ok := r.credHelpers[registry]; ok {
+		return runCredentialHelper(helper, registry)
+	}
+	if len(r.credsStore) > 0 {
+		if creds, err := runCredentialHelper(r.credsStore, registry); err == nil {
+			return creds, nil
+		}
+	}
+	if entry, ok := r.entries[registry]; ok {
+		return decodeEntry(entry)
+	}
+	for _, p := range r.Providers {
+		if creds, ok, err := p.Provide(registry); ok {
+			return creds, err
+		}
+	}
+	return Credentials{}, fmt.Errorf("no credentials found for registry %q", registry)
+}
+
+func decodeEntry(entry dockerConfigEntry) (Credentials, error) {
+	if len(entry.Username) > 0 {
+		return Credentials{Username: entry.Username, Password: entry.Password}, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("unable to decode auth entry: %v", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return Credentials{}, fmt.Errorf("malformed auth entry")
+	}
+	return Credentials{Username: username, Password: password}, nil
+}
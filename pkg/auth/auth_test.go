@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDockerCfg(t *testing.T, dir, name string, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestResolverDockercfgAuthEntry(t *testing.T) {
+	dir := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("alice:s3cret"))
+	path := writeDockerCfg(t, dir, "config.json", `{"auths":{"quay.io":{"auth":"`+auth+`"}}}`)
+
+	r, err := NewResolver([]string{path})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	creds, err := r.Resolve("quay.io")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if creds.Username != "alice" || creds.Password != "s3cret" {
+		t.Fatalf("got %+v, want alice/s3cret", creds)
+	}
+}
+
+func TestResolverDockercfgUsernamePasswordFields(t *testing.T) {
+	dir := t.TempDir()
+	path := writeDockerCfg(t, dir, "config.json", `{"auths":{"quay.io":{"username":"bob","password":"hunter2"}}}`)
+
+	r, err := NewResolver([]string{path})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	creds, err := r.Resolve("quay.io")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if creds.Username != "bob" || creds.Password != "hunter2" {
+		t.Fatalf("got %+v, want bob/hunter2", creds)
+	}
+}
+
+func TestResolverLaterFileTakesPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	first := writeDockerCfg(t, dir, "first.json", `{"auths":{"quay.io":{"username":"old","password":"old"}}}`)
+	second := writeDockerCfg(t, dir, "second.json", `{"auths":{"quay.io":{"username":"new","password":"new"}}}`)
+
+	r, err := NewResolver([]string{first, second})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	creds, err := r.Resolve("quay.io")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if creds.Username != "new" {
+		t.Fatalf("got username %q, want %q (later file should win)", creds.Username, "new")
+	}
+}
+
+func TestResolverNoMatchFallsThroughToProviders(t *testing.T) {
+	r := &Resolver{entries: map[string]dockerConfigEntry{}, credHelpers: map[string]string{}}
+	r.Providers = []CredentialProvider{
+		fakeProvider{name: "other", registry: "other.example.com"},
+		fakeProvider{name: "ecr", registry: "123456789.dkr.ecr.us-east-1.amazonaws.com", creds: Credentials{Username: "AWS", Password: "token"}},
+	}
+
+	creds, err := r.Resolve("123456789.dkr.ecr.us-east-1.amazonaws.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if creds.Username != "AWS" || creds.Password != "token" {
+		t.Fatalf("got %+v, want AWS/token from the matching provider", creds)
+	}
+}
+
+func TestResolverNoMatchAnywhereErrors(t *testing.T) {
+	r := &Resolver{entries: map[string]dockerConfigEntry{}, credHelpers: map[string]string{}}
+	if _, err := r.Resolve("unknown.example.com"); err == nil {
+		t.Fatalf("expected an error when no entry, helper or provider matches")
+	}
+}
+
+type fakeProvider struct {
+	name     string
+	registry string
+	creds    Credentials
+}
+
+func (p fakeProvider) Name() string { return p.name }
+
+func (p fakeProvider) Provide(registry string) (Credentials, bool, error) {
+	if registry != p.registry {
+		return Credentials{}, false, nil
+	}
+	return p.creds, true, nil
+}
+
+func TestRegistryHostname(t *testing.T) {
+	cases := map[string]string{
+		"busybox":                   "docker.io",
+		"busybox:latest":            "docker.io",
+		"library/busybox":           "docker.io",
+		"quay.io/foo/bar:tag":       "quay.io",
+		"localhost:5000/foo":        "localhost:5000",
+		"localhost/foo":             "localhost",
+		"registry.example.com/foo":  "registry.example.com",
+		"docker.io/library/busybox": "docker.io",
+		"foo@sha256:deadbeef":       "docker.io",
+	}
+	for image, want := range cases {
+		if got := RegistryHostname(image); got != want {
+			t.Errorf("RegistryHostname(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// runCredentialHelper shells out to the docker-credential-<helper>
+// binary's "get" command, the same protocol docker and podman use for
+// credHelpers/credsStore entries.
+func runCredentialHelper(helper, registry string) (Credentials, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = bytes.NewBufferString(registry)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("docker-credential-%s get failed: %v", helper, err)
+	}
+
+	var resp struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Credentials{}, fmt.Errorf("unable to parse docker-credential-%s output: %v", helper, err)
+	}
+	return Credentials{Username: resp.Username, Password: resp.Secret}, nil
+}
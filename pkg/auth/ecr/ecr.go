@@ -0,0 +1,61 @@
+// Package ecr provides an auth.CredentialProvider backed by AWS ECR's
+// GetAuthorizationToken API, for pulling from *.dkr.ecr.*.amazonaws.com
+// registries without a dockercfg entry.
+package ecr
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+
+	"github.com/openshift/image-inspector/pkg/auth"
+)
+
+func init() {
+	auth.RegisterProvider(&Provider{})
+}
+
+// Provider resolves ECR registry authorization tokens.
+type Provider struct{}
+
+// Name implements auth.CredentialProvider.
+func (p *Provider) Name() string {
+	return "ecr"
+}
+
+// Provide implements auth.CredentialProvider. It only handles registries
+// that look like an ECR registry hostname; any other registry is left
+// for the next provider in the chain.
+func (p *Provider) Provide(registry string) (auth.Credentials, bool, error) {
+	if !strings.Contains(registry, ".dkr.ecr.") {
+		return auth.Credentials{}, false, nil
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return auth.Credentials{}, true, fmt.Errorf("unable to load AWS config: %v", err)
+	}
+	client := ecr.NewFromConfig(cfg)
+	out, err := client.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return auth.Credentials{}, true, fmt.Errorf("unable to get ECR authorization token: %v", err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return auth.Credentials{}, true, fmt.Errorf("ECR returned no authorization data")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(*out.AuthorizationData[0].AuthorizationToken)
+	if err != nil {
+		return auth.Credentials{}, true, fmt.Errorf("unable to decode ECR authorization token: %v", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return auth.Credentials{}, true, fmt.Errorf("malformed ECR authorization token")
+	}
+	return auth.Credentials{Username: username, Password: password}, true, nil
+}
@@ -0,0 +1,45 @@
+// Package gcr provides an auth.CredentialProvider backed by Google's
+// Application Default Credentials, for pulling from gcr.io and
+// *-docker.pkg.dev registries without a dockercfg entry.
+package gcr
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/openshift/image-inspector/pkg/auth"
+)
+
+func init() {
+	auth.RegisterProvider(&Provider{})
+}
+
+// Provider resolves GCR/Artifact Registry access tokens.
+type Provider struct{}
+
+// Name implements auth.CredentialProvider.
+func (p *Provider) Name() string {
+	return "gcr"
+}
+
+// Provide implements auth.CredentialProvider. It only handles GCR and
+// Artifact Registry hostnames; any other registry is left for the next
+// provider in the chain.
+func (p *Provider) Provide(registry string) (auth.Credentials, bool, error) {
+	if !strings.HasSuffix(registry, "gcr.io") && !strings.HasSuffix(registry, "-docker.pkg.dev") {
+		return auth.Credentials{}, false, nil
+	}
+
+	ctx := context.Background()
+	creds, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/devstorage.read_only")
+	if err != nil {
+		return auth.Credentials{}, true, err
+	}
+	token, err := creds.TokenSource.Token()
+	if err != nil {
+		return auth.Credentials{}, true, err
+	}
+	return auth.Credentials{Username: "oauth2accesstoken", Password: token.AccessToken}, true, nil
+}
@@ -0,0 +1,27 @@
+package auth
+
+var providers = map[string]CredentialProvider{}
+
+// RegisterProvider adds p to the set of known cloud credential
+// providers under p.Name(). Provider packages (pkg/auth/ecr,
+// pkg/auth/gcr, pkg/auth/acr) are expected to call RegisterProvider from
+// their init() function.
+func RegisterProvider(p CredentialProvider) {
+	providers[p.Name()] = p
+}
+
+// ProviderNames lists every registered cloud credential provider name.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Provider returns the cloud credential provider registered under name,
+// if any.
+func Provider(name string) (CredentialProvider, bool) {
+	p, ok := providers[name]
+	return p, ok
+}
@@ -0,0 +1,30 @@
+package auth
+
+import "strings"
+
+// DefaultRegistry is the registry hostname assumed for image names whose
+// first path segment doesn't look like a host, mirroring kubelet
+// credentialprovider's handling of bare Docker Hub references like
+// "library/busybox" or "busybox".
+const DefaultRegistry = "docker.io"
+
+// RegistryHostname extracts the registry hostname Resolve should be
+// queried with from imageName, e.g. "quay.io/foo/bar:tag" -> "quay.io",
+// "busybox:latest" -> "docker.io".
+func RegistryHostname(imageName string) string {
+	name := imageName
+	if i := strings.IndexByte(name, '@'); i >= 0 {
+		name = name[:i]
+	}
+
+	firstSlash := strings.IndexByte(name, '/')
+	if firstSlash < 0 {
+		return DefaultRegistry
+	}
+	host := name[:firstSlash]
+
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return DefaultRegistry
+	}
+	return host
+}
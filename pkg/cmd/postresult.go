@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+	"github.com/openshift/image-inspector/pkg/server"
+)
+
+// PostResults marshals results as JSON and POSTs them to i.PostResultURL,
+// appending the token read from i.PostResultTokenFile (if set) as a
+// "token" query parameter. The request is made through a cancelable
+// transport (see pkg/server) so a caller-cancelled ctx aborts the POST
+// instead of leaking it.
+func (i *ImageInspectorOptions) PostResults(ctx context.Context, results []iiapi.ScanResult) error {
+	if len(i.PostResultURL) == 0 {
+		return nil
+	}
+
+	url := i.PostResultURL
+	if len(i.PostResultTokenFile) > 0 {
+		token, err := os.ReadFile(i.PostResultTokenFile)
+		if err != nil {
+			return fmt.Errorf("unable to read post-results-token-file: %v", err)
+		}
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		url = fmt.Sprintf("%s%stoken=%s", url, sep, strings.TrimSpace(string(token)))
+	}
+
+	body, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("unable to marshal scan results: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to build post-results request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := server.NewPostResultClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to post scan results to %s: %v", i.PostResultURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("posting scan results to %s failed with status %s", i.PostResultURL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containers/image/v5/types"
+
+	"github.com/openshift/image-inspector/pkg/auth"
+	"github.com/openshift/image-inspector/pkg/transport"
+)
+
+// Pull resolves i.Image through i.Transport and pulls+unpacks it into
+// i.DstPath, using pkg/auth to resolve the per-registry credentials to
+// pull with from i.DockerCfg and i.CredentialProviders. It is the
+// containers/image-backed counterpart to the docker-daemon pull path,
+// used whenever i.Transport is not "docker-daemon".
+func (i *ImageInspectorOptions) Pull(ctx context.Context) error {
+	ref, err := transport.ParseReference(i.Transport, i.Image)
+	if err != nil {
+		return fmt.Errorf("unable to resolve image %s: %v", i.Image, err)
+	}
+
+	sys, err := i.pullSystemContext()
+	if err != nil {
+		return err
+	}
+
+	return transport.Pull(ctx, ref, i.DstPath, sys)
+}
+
+// pullSystemContext builds the *types.SystemContext that carries the
+// credentials resolved for i.Image's registry, or an empty one if none
+// apply, so an anonymous pull still works.
+func (i *ImageInspectorOptions) pullSystemContext() (*types.SystemContext, error) {
+	sys := &types.SystemContext{}
+
+	resolver, err := auth.NewResolver(i.DockerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build credential resolver: %v", err)
+	}
+	for _, name := range i.CredentialProviders {
+		if p, ok := auth.Provider(name); ok {
+			resolver.Providers = append(resolver.Providers, p)
+		}
+	}
+
+	creds, err := resolver.Resolve(auth.RegistryHostname(i.Image))
+	if err != nil {
+		// No credentials matched; fall through and pull anonymously, the
+		// same as the docker-daemon path does when Username/DockerCfg are
+		// unset.
+		return sys, nil
+	}
+	sys.DockerAuthConfig = &types.DockerAuthConfig{
+		Username: creds.Username,
+		Password: creds.Password,
+	}
+	return sys, nil
+}
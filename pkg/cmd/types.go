@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	oscapscanner "github.com/openshift/image-inspector/pkg/openscap"
 
@@ -9,9 +11,25 @@ import (
 
 	"os"
 
+	"github.com/openshift/image-inspector/pkg/auth"
+	// Built-in cloud credential providers register themselves on import.
+	_ "github.com/openshift/image-inspector/pkg/auth/acr"
+	_ "github.com/openshift/image-inspector/pkg/auth/ecr"
+	_ "github.com/openshift/image-inspector/pkg/auth/gcr"
+	"github.com/openshift/image-inspector/pkg/report"
+	"github.com/openshift/image-inspector/pkg/scanner"
+	// Built-in pluggable scanners register themselves on import.
+	_ "github.com/openshift/image-inspector/pkg/scanner/clair"
+	_ "github.com/openshift/image-inspector/pkg/scanner/grype"
+	_ "github.com/openshift/image-inspector/pkg/scanner/trivy"
+	"github.com/openshift/image-inspector/pkg/transport"
 	util "github.com/openshift/image-inspector/pkg/util"
 )
 
+// legacyScanTypes are the scan types that are not yet backed by the
+// pkg/scanner registry.
+var legacyScanTypes = []string{"openscap", "clamav"}
+
 const DefaultDockerSocketLocation = "unix:///var/run/docker.sock"
 
 // MultiStringVar is implementing flag.Value
@@ -30,30 +48,77 @@ func (sv *MultiStringVar) String() string {
 // for an image inspector.
 type ImageInspectorOptions struct {
 	// URI contains the location of the docker daemon socket to connect to.
+	// It is only required when Transport is "docker-daemon" or unset; the
+	// other containers/image-backed transports resolve Image on their own.
 	URI string
+	// Transport selects how Image is pulled and unpacked: "docker-daemon"
+	// (the default, via the docker socket at URI), or one of the
+	// containers/image transports "docker", "dir", "oci" or
+	// "containers-storage" (see pkg/transport), none of which require a
+	// running Docker daemon.
+	Transport string
 	// Image contains the docker image to inspect.
 	Image string
 	// Container contains the docker container to inspect.
 	Container string
 	// ScanContainerChanges controls whether or not whole rootfs will be scanned.
 	ScanContainerChanges bool
-	// DstPath is the destination path for image files.
+	// DstPath is the destination path for image files. When Transport is
+	// a containers/image transport, the image is pulled and unpacked
+	// directly into DstPath via pkg/transport instead of the docker
+	// daemon's export/extract path.
 	DstPath string
 	// Serve holds the host and port for where to serve the image with webdav.
 	Serve string
 	// Chroot controls whether or not a chroot is excuted when serving the image with webdav.
 	Chroot bool
-	// DockerCfg is the location of the docker config file.
+	// ServeAuthBasicFile is the location of an htpasswd-style file used
+	// to authenticate WebDAV requests with HTTP basic auth. AuthToken, if
+	// set, is accepted as a bearer-token alternative.
+	ServeAuthBasicFile string
+	// ServeTLSCert and ServeTLSKey are the location of a certificate/key
+	// pair used to serve WebDAV over TLS. Both must be set to enable TLS.
+	ServeTLSCert string
+	ServeTLSKey  string
+	// ServePrefix is a URL path prefix under which the WebDAV filesystem
+	// is mounted, e.g. "/rootfs".
+	ServePrefix string
+	// ServeReadOnly allows unauthenticated PROPFIND/OPTIONS/GET requests
+	// so read-only mounts by scanners work without credentials, while
+	// writes still require ServeAuthBasicFile or AuthToken.
+	ServeReadOnly bool
+	// DockerCfg is the location of the docker config file(s). When more
+	// than one is given, they are merged by registry hostname (see
+	// pkg/auth), with later files taking precedence. A pull resolves one
+	// credential, for the registry of Image, and uses it for the whole
+	// pull; layers served from other registries get no credential of
+	// their own.
 	DockerCfg MultiStringVar
 	// Username is the username for authenticating to the docker registry.
 	Username string
 	// PasswordFile is the location of the file containing the password for authentication to the
 	// docker registry.
 	PasswordFile string
-	// ScanTypes are the types of scans to be done on the inspected image
+	// CredentialProviders selects cloud credential providers (see
+	// pkg/auth) to consult, in order, when no DockerCfg entry or
+	// credHelpers/credsStore entry matches a registry, e.g.
+	// "ecr,gcr".
+	CredentialProviders MultiStringVar
+	// ScanTypes are the types of scans to be done on the inspected image.
+	// Besides the built-in "openscap" and "clamav" types, this accepts
+	// the name of any scanner registered in pkg/scanner (e.g. "trivy",
+	// "grype", "clair").
 	ScanTypes MultiStringVar
+	// ScannerOpts holds scanner-specific options in "scanner.key=value"
+	// form, e.g. "trivy.db-path=/var/lib/trivy", and is applied to the
+	// matching pkg/scanner registry entry.
+	ScannerOpts MultiStringVar
 	// ScanResultsDir is the directory that will contain the results of the scan
 	ScanResultsDir string
+	// ReportFormats are additional formats (see pkg/report) that the
+	// combined scan findings will be serialized to, written alongside the
+	// native results into ScanResultsDir, e.g. "sarif", "cyclonedx".
+	ReportFormats MultiStringVar
 	// OpenScapHTML controls whether or not to generate an HTML report
 	// TODO: Move this into openscap plugin options.
 	OpenScapHTML bool
@@ -68,6 +133,10 @@ type ImageInspectorOptions struct {
 	// PostResultTokenFile if specified the content of the file will be added as a token to
 	// the result POST URL (eg. http://foo/?token=CONTENT.
 	PostResultTokenFile string
+	// ScanTimeout bounds how long a single scanner may run when invoked
+	// through the streaming /api/v2/scan endpoint (see pkg/server); zero
+	// means no timeout beyond the client's own context.
+	ScanTimeout time.Duration
 	// AuthToken is a Shared Secret used to validate HTTP Requests.
 	// AuthToken can be set through AuthTokenFile or ENV
 	AuthToken string
@@ -76,22 +145,39 @@ type ImageInspectorOptions struct {
 	AuthTokenFile string
 	// PullPolicy controls whether we try to pull the inspected image
 	PullPolicy string
+	// CommitImage is the destination OCI layout reference (see
+	// pkg/commit) that the scanned rootfs is committed to after a scan,
+	// e.g. "/out/image:scanned". Empty means don't commit.
+	CommitImage string
+	// CommitLabels are additional labels to set on the committed image's
+	// config, beyond the built-in org.opencontainers.image.scan.* labels.
+	CommitLabels MultiStringVar
 }
 
 // NewDefaultImageInspectorOptions provides a new ImageInspectorOptions with default values.
 func NewDefaultImageInspectorOptions() *ImageInspectorOptions {
 	return &ImageInspectorOptions{
-		URI:        DefaultDockerSocketLocation,
-		DockerCfg:  MultiStringVar{},
-		ScanTypes:  MultiStringVar{},
-		CVEUrlPath: oscapscanner.CVEUrl,
-		PullPolicy: iiapi.PullIfNotPresent,
+		URI:                 DefaultDockerSocketLocation,
+		Transport:           transport.DockerDaemon,
+		DockerCfg:           MultiStringVar{},
+		ScanTypes:           MultiStringVar{},
+		ScannerOpts:         MultiStringVar{},
+		ReportFormats:       MultiStringVar{},
+		CredentialProviders: MultiStringVar{},
+		CVEUrlPath:          oscapscanner.CVEUrl,
+		PullPolicy:          iiapi.PullIfNotPresent,
+		ScanTimeout:         30 * time.Second,
+		CommitLabels:        MultiStringVar{},
 	}
 }
 
 // Validate performs validation on the field settings.
 func (i *ImageInspectorOptions) Validate() error {
-	if len(i.URI) == 0 {
+	if len(i.Transport) > 0 && !util.StringInList(i.Transport, transport.Names) {
+		return fmt.Errorf("%s is not one of the available transports which are %v",
+			i.Transport, transport.Names)
+	}
+	if transport.RequiresURI(i.Transport) && len(i.URI) == 0 {
 		return fmt.Errorf("docker socket connection must be specified")
 	}
 	if len(i.Image) > 0 && len(i.Container) > 0 {
@@ -100,6 +186,9 @@ func (i *ImageInspectorOptions) Validate() error {
 	if len(i.Image) == 0 && len(i.Container) == 0 {
 		return fmt.Errorf("docker image or container must be specified to inspect")
 	}
+	if len(i.Container) > 0 && !transport.RequiresURI(i.Transport) {
+		return fmt.Errorf("container can only be used with the docker-daemon transport, not %q", i.Transport)
+	}
 	if i.ScanContainerChanges && len(i.Container) == 0 {
 		return fmt.Errorf("please specify docker container")
 	}
@@ -112,6 +201,12 @@ func (i *ImageInspectorOptions) Validate() error {
 	if len(i.Serve) == 0 && i.Chroot {
 		return fmt.Errorf("change root can be used only when serving the image through webdav")
 	}
+	if len(i.Serve) == 0 && (len(i.ServeAuthBasicFile) > 0 || len(i.ServeTLSCert) > 0 || len(i.ServeTLSKey) > 0 || len(i.ServePrefix) > 0 || i.ServeReadOnly) {
+		return fmt.Errorf("serve-auth-basic-file, serve-tls-cert, serve-tls-key, serve-prefix and serve-read-only can be used only when serving the image through webdav")
+	}
+	if (len(i.ServeTLSCert) > 0) != (len(i.ServeTLSKey) > 0) {
+		return fmt.Errorf("serve-tls-cert and serve-tls-key must be specified together")
+	}
 	if len(i.ScanResultsDir) > 0 && len(i.ScanTypes) == 0 {
 		return fmt.Errorf("scan-result-dir can be used only when spacifing scan-type")
 	}
@@ -134,19 +229,57 @@ func (i *ImageInspectorOptions) Validate() error {
 			}
 		}
 	}
+	for _, p := range i.CredentialProviders {
+		if _, ok := auth.Provider(p); !ok {
+			return fmt.Errorf("%s is not one of the available credential-providers which are %v",
+				p, auth.ProviderNames())
+		}
+	}
 	if util.StringInList("clamav", i.ScanTypes) && len(i.ClamSocket) == 0 {
 		return fmt.Errorf("clam-socket must be set to use clamav scan type")
 	}
 
-	// A scan-types must be valid.
+	// A scan-type must either be a legacy built-in or a scanner
+	// registered in pkg/scanner.
 	if len(i.ScanTypes) > 0 {
+		available := append(append([]string{}, legacyScanTypes...), scanner.Names()...)
 		for _, v := range i.ScanTypes {
-			if !util.StringInList(v, iiapi.ScanOptions) {
+			if util.StringInList(v, legacyScanTypes) {
+				continue
+			}
+			if _, ok := scanner.Get(v); !ok {
 				return fmt.Errorf("%s is not one of the available scan-types which are %v",
-					v, iiapi.ScanOptions)
+					v, available)
+			}
+		}
+	}
+	if err := scanner.ApplyOpts(i.ScannerOpts); err != nil {
+		return err
+	}
+	for _, v := range i.ScanTypes {
+		if s, ok := scanner.Get(v); ok {
+			if err := s.ValidateOptions(); err != nil {
+				return fmt.Errorf("%s scanner options are invalid: %v", v, err)
 			}
 		}
 	}
+	if len(i.ReportFormats) > 0 && len(i.ScanTypes) == 0 {
+		return fmt.Errorf("report-format can be used only when specifying scan-type")
+	}
+	for _, v := range i.ReportFormats {
+		if !util.StringInList(v, report.Formats) {
+			return fmt.Errorf("%s is not one of the available report-formats which are %v",
+				v, report.Formats)
+		}
+	}
+	if len(i.CommitLabels) > 0 && len(i.CommitImage) == 0 {
+		return fmt.Errorf("commit-label can be used only when specifying commit-image")
+	}
+	for _, l := range i.CommitLabels {
+		if !strings.Contains(l, "=") {
+			return fmt.Errorf("commit-label %q must be in key=value form", l)
+		}
+	}
 	if !util.StringInList(i.PullPolicy, iiapi.PullPolicyOptions) {
 		return fmt.Errorf("%s is not one of the available pull-policy options which are %v",
 			i.PullPolicy, iiapi.PullPolicyOptions)
@@ -0,0 +1,104 @@
+package commit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// amendConfig rewrites dest's OCI config to carry labels and, if set,
+// embeds vexDigest as a manifest annotation, the way `buildah commit`
+// amends a container's config after the copy.
+func amendConfig(ctx context.Context, dest types.ImageReference, labels map[string]string, vexDigest string) error {
+	sys := &types.SystemContext{}
+
+	src, err := dest.NewImageSource(ctx, sys)
+	if err != nil {
+		return fmt.Errorf("unable to open committed image for amending: %v", err)
+	}
+	defer src.Close()
+
+	manifestBytes, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to read committed manifest: %v", err)
+	}
+	if mimeType != imgspecv1.MediaTypeImageManifest {
+		return fmt.Errorf("commit only supports amending OCI manifests, got %s", mimeType)
+	}
+	oci, err := manifest.OCI1FromManifest(manifestBytes)
+	if err != nil {
+		return fmt.Errorf("unable to parse committed manifest: %v", err)
+	}
+
+	configBlob, err := readBlob(ctx, src, oci.ConfigInfo())
+	if err != nil {
+		return fmt.Errorf("unable to read committed image config: %v", err)
+	}
+	var config imgspecv1.Image
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return fmt.Errorf("unable to parse committed image config: %v", err)
+	}
+	if config.Config.Labels == nil {
+		config.Config.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		config.Config.Labels[k] = v
+	}
+	newConfigBlob, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("unable to marshal amended image config: %v", err)
+	}
+	newConfigInfo := types.BlobInfo{
+		Digest:    digest.FromBytes(newConfigBlob),
+		Size:      int64(len(newConfigBlob)),
+		MediaType: oci.ConfigInfo().MediaType,
+	}
+
+	dst, err := dest.NewImageDestination(ctx, sys)
+	if err != nil {
+		return fmt.Errorf("unable to open committed image destination for amending: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := dst.PutBlob(ctx, bytes.NewReader(newConfigBlob), newConfigInfo, nil, true); err != nil {
+		return fmt.Errorf("unable to write amended image config: %v", err)
+	}
+
+	oci.Config = imgspecv1.Descriptor{
+		MediaType: newConfigInfo.MediaType,
+		Digest:    newConfigInfo.Digest,
+		Size:      newConfigInfo.Size,
+	}
+	if len(vexDigest) > 0 {
+		if oci.Annotations == nil {
+			oci.Annotations = map[string]string{}
+		}
+		oci.Annotations[AnnotationVEXDigest] = vexDigest
+	}
+
+	amendedManifest, err := oci.Serialize()
+	if err != nil {
+		return fmt.Errorf("unable to serialize amended manifest: %v", err)
+	}
+	if err := dst.PutManifest(ctx, amendedManifest, nil); err != nil {
+		return fmt.Errorf("unable to write amended manifest: %v", err)
+	}
+	return dst.Commit(ctx, nil)
+}
+
+// readBlob fetches and fully reads the blob described by info from src.
+func readBlob(ctx context.Context, src types.ImageSource, info types.BlobInfo) ([]byte, error) {
+	r, _, err := src.GetBlob(ctx, info, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,118 @@
+// Package commit implements a buildah-style commit of a scanned rootfs
+// into a new OCI image, whose config and manifest carry scan metadata as
+// labels and annotations. This lets downstream registries or admission
+// controllers gate deploys on "has been scanned and passed" without a
+// separate attestation store.
+package commit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+)
+
+// Label names applied to the committed image's config, mirroring the
+// org.opencontainers.image.* label convention.
+const (
+	LabelCVECount  = "org.opencontainers.image.scan.cve-count"
+	LabelTimestamp = "org.opencontainers.image.scan.timestamp"
+	LabelTool      = "org.opencontainers.image.scan.tool"
+	// AnnotationVEXDigest holds the digest of the CycloneDX VEX document
+	// (see pkg/report) produced for this scan, embedded in the
+	// manifest's annotations.
+	AnnotationVEXDigest = "org.opencontainers.image.scan.vex-digest"
+)
+
+// Options configures a single commit.
+type Options struct {
+	// Rootfs is the (possibly modified) unpacked container filesystem to
+	// commit.
+	Rootfs string
+	// Dest is the destination OCI layout reference in path[:image] form,
+	// e.g. "/out/image:scanned".
+	Dest string
+	// Labels are additional labels to set on the committed image's
+	// config, beyond the built-in scan.* labels; see
+	// ImageInspectorOptions.CommitLabels.
+	Labels map[string]string
+	// Results are the normalized findings from every scanner that ran,
+	// used to populate the built-in scan.* labels.
+	Results []iiapi.ScanResult
+	// VEXDigest is the digest of the CycloneDX VEX document for this
+	// scan, if one was generated (see pkg/report), embedded as a
+	// manifest annotation.
+	VEXDigest string
+}
+
+// Commit copies opts.Rootfs into an OCI image at opts.Dest via
+// containers/image, synthesizing a config whose labels record that the
+// image has been scanned, by what, and with what result.
+func Commit(ctx context.Context, opts Options) error {
+	destRef, err := layout.ParseReference(opts.Dest)
+	if err != nil {
+		return fmt.Errorf("unable to resolve commit destination %s: %v", opts.Dest, err)
+	}
+
+	// opts.Rootfs is a plain directory of real files, not an OCI layout,
+	// so it has to be turned into one (a tarred layer plus a generated
+	// config/manifest) before copy.Image has anything valid to read.
+	srcRef, srcDir, err := synthesizeSource(opts.Rootfs)
+	if err != nil {
+		return fmt.Errorf("unable to synthesize an image from %s: %v", opts.Rootfs, err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	policy, err := signature.DefaultPolicy(&types.SystemContext{})
+	if err != nil {
+		return fmt.Errorf("unable to load signature policy: %v", err)
+	}
+	policyCtx, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return fmt.Errorf("unable to create policy context: %v", err)
+	}
+	defer policyCtx.Destroy()
+
+	labels := scanLabels(opts)
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+
+	_, err = copy.Image(ctx, policyCtx, destRef, srcRef, &copy.Options{
+		// The destination's config/manifest is annotated after the copy
+		// by amending it with the scan labels computed above; see
+		// amendConfig.
+	})
+	if err != nil {
+		return fmt.Errorf("unable to commit scanned image: %v", err)
+	}
+	return amendConfig(ctx, destRef, labels, opts.VEXDigest)
+}
+
+func scanLabels(opts Options) map[string]string {
+	cveCount := 0
+	tools := map[string]bool{}
+	for _, r := range opts.Results {
+		cveCount += len(r.Findings)
+		tools[r.Name] = true
+	}
+	toolList := ""
+	for t := range tools {
+		if len(toolList) > 0 {
+			toolList += ","
+		}
+		toolList += t
+	}
+	return map[string]string{
+		LabelCVECount:  fmt.Sprintf("%d", cveCount),
+		LabelTimestamp: time.Now().UTC().Format(time.RFC3339),
+		LabelTool:      toolList,
+	}
+}
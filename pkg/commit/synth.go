@@ -0,0 +1,199 @@
+package commit
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspec "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// synthesizeSource builds a minimal, valid OCI image layout from rootfs
+// on disk -- a single gzip-compressed tar layer plus a generated config
+// and manifest -- so it can be fed into containers/image's copy.Image as
+// a source. Rootfs is a plain directory of real files, not an existing
+// OCI layout, so it cannot be handed to layout.ParseReference directly.
+// The caller is responsible for removing the returned directory once
+// done.
+func synthesizeSource(rootfs string) (types.ImageReference, string, error) {
+	dir, err := os.MkdirTemp("", "image-inspector-commit-")
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create layout dir: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755); err != nil {
+		return nil, "", fmt.Errorf("unable to create layout blobs dir: %v", err)
+	}
+
+	layerDigest, layerDiffID, layerSize, err := writeLayer(dir, rootfs)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to tar rootfs into a layer: %v", err)
+	}
+
+	config := imgspecv1.Image{
+		RootFS: imgspecv1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{layerDiffID},
+		},
+	}
+	configDigest, configSize, err := writeJSONBlob(dir, config)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to write synthesized config: %v", err)
+	}
+
+	man := imgspecv1.Manifest{
+		Versioned: imgspec.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config: imgspecv1.Descriptor{
+			MediaType: imgspecv1.MediaTypeImageConfig,
+			Digest:    configDigest,
+			Size:      configSize,
+		},
+		Layers: []imgspecv1.Descriptor{{
+			MediaType: imgspecv1.MediaTypeImageLayerGzip,
+			Digest:    layerDigest,
+			Size:      layerSize,
+		}},
+	}
+	manifestDigest, manifestSize, err := writeJSONBlob(dir, man)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to write synthesized manifest: %v", err)
+	}
+
+	index := imgspecv1.Index{
+		Versioned: imgspec.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{{
+			MediaType: imgspecv1.MediaTypeImageManifest,
+			Digest:    manifestDigest,
+			Size:      manifestSize,
+		}},
+	}
+	if err := writeJSONFile(filepath.Join(dir, "index.json"), index); err != nil {
+		return nil, "", fmt.Errorf("unable to write index.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		return nil, "", fmt.Errorf("unable to write oci-layout: %v", err)
+	}
+
+	ref, err := layout.ParseReference(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to resolve synthesized layout: %v", err)
+	}
+	return ref, dir, nil
+}
+
+// writeLayer tars rootfs into a single gzip-compressed layer blob under
+// layoutDir/blobs/sha256, returning the blob's own digest (what the
+// manifest's layer descriptor references), its uncompressed digest (the
+// config's DiffID), and the compressed blob's size.
+func writeLayer(layoutDir, rootfs string) (digest.Digest, digest.Digest, int64, error) {
+	tmp, err := os.CreateTemp(layoutDir, "layer-")
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	diffHash := sha256.New()
+	blobHash := sha256.New()
+
+	gz := gzip.NewWriter(io.MultiWriter(tmp, blobHash))
+	tw := tar.NewWriter(io.MultiWriter(gz, diffHash))
+
+	err = filepath.Walk(rootfs, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(rootfs, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return "", "", 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return "", "", 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return "", "", 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", "", 0, err
+	}
+	blobDigest := digest.NewDigestFromBytes(digest.SHA256, blobHash.Sum(nil))
+	diffID := digest.NewDigestFromBytes(digest.SHA256, diffHash.Sum(nil))
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", "", 0, err
+	}
+	dst, err := os.Create(filepath.Join(layoutDir, "blobs", "sha256", blobDigest.Encoded()))
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, tmp); err != nil {
+		return "", "", 0, err
+	}
+
+	return blobDigest, diffID, size, nil
+}
+
+// writeJSONBlob marshals v and writes it under layoutDir/blobs/sha256,
+// keyed by its own digest, returning that digest and the blob's size.
+func writeJSONBlob(layoutDir string, v interface{}) (digest.Digest, int64, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", 0, err
+	}
+	d := digest.FromBytes(b)
+	if err := os.WriteFile(filepath.Join(layoutDir, "blobs", "sha256", d.Encoded()), b, 0644); err != nil {
+		return "", 0, err
+	}
+	return d, int64(len(b)), nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
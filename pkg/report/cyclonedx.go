@@ -0,0 +1,63 @@
+package report
+
+import iiapi "github.com/openshift/image-inspector/pkg/api"
+
+// cyclonedxDoc is the subset of the CycloneDX 1.5 schema that
+// image-inspector populates: an SBOM component list plus a VEX-style
+// vulnerabilities array associating each finding with the component it
+// affects.
+type cyclonedxDoc struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	Components      []cyclonedxComponent     `json:"components"`
+	Vulnerabilities []cyclonedxVulnerability `json:"vulnerabilities"`
+}
+
+type cyclonedxComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	BOMRef  string `json:"bom-ref"`
+}
+
+type cyclonedxVulnerability struct {
+	ID      string             `json:"id"`
+	Ratings []cyclonedxRating  `json:"ratings"`
+	Affects []cyclonedxAffects `json:"affects"`
+}
+
+type cyclonedxRating struct {
+	Severity string `json:"severity"`
+}
+
+type cyclonedxAffects struct {
+	Ref string `json:"ref"`
+}
+
+func toCycloneDX(results []iiapi.ScanResult) *cyclonedxDoc {
+	doc := &cyclonedxDoc{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+	}
+	seen := map[string]bool{}
+	for _, r := range results {
+		for _, f := range r.Findings {
+			ref := f.Package + "@" + f.Version
+			if !seen[ref] {
+				doc.Components = append(doc.Components, cyclonedxComponent{
+					Type:    "library",
+					Name:    f.Package,
+					Version: f.Version,
+					BOMRef:  ref,
+				})
+				seen[ref] = true
+			}
+			doc.Vulnerabilities = append(doc.Vulnerabilities, cyclonedxVulnerability{
+				ID:      f.CVE,
+				Ratings: []cyclonedxRating{{Severity: f.Severity}},
+				Affects: []cyclonedxAffects{{Ref: ref}},
+			})
+		}
+	}
+	return doc
+}
@@ -0,0 +1,54 @@
+// Package report converts the normalized findings produced by pkg/scanner
+// scan backends into SARIF and CycloneDX documents, so downstream
+// consumers (GitHub/GitLab code scanning, supply-chain tooling) do not
+// need to parse OpenSCAP XML or ARF directly.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+)
+
+// Format names accepted by the --report-format flag.
+const (
+	FormatSARIF     = "sarif"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// Formats lists the report formats image-inspector knows how to write.
+var Formats = []string{FormatSARIF, FormatCycloneDX}
+
+// Write renders results in each of the given formats and writes them to
+// dir, using the conventional file name for each format (e.g.
+// results.sarif.json, results.cyclonedx.json).
+func Write(dir string, results []iiapi.ScanResult, formats []string) error {
+	for _, f := range formats {
+		var (
+			doc  interface{}
+			name string
+		)
+		switch f {
+		case FormatSARIF:
+			doc = toSARIF(results)
+			name = "results.sarif.json"
+		case FormatCycloneDX:
+			doc = toCycloneDX(results)
+			name = "results.cyclonedx.json"
+		default:
+			return fmt.Errorf("unknown report-format %q", f)
+		}
+
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal %s report: %v", f, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return fmt.Errorf("unable to write %s report: %v", f, err)
+		}
+	}
+	return nil
+}
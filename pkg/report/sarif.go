@@ -0,0 +1,80 @@
+package report
+
+import (
+	"strings"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+)
+
+// sarifDoc is the subset of the SARIF 2.1.0 schema that image-inspector
+// populates from scan findings.
+type sarifDoc struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID  string       `json:"ruleId"`
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+func toSARIF(results []iiapi.ScanResult) *sarifDoc {
+	doc := &sarifDoc{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+	}
+	for _, r := range results {
+		run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: r.Name}}}
+		seen := map[string]bool{}
+		for _, f := range r.Findings {
+			if !seen[f.CVE] {
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: f.CVE})
+				seen[f.CVE] = true
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  f.CVE,
+				Level:   sarifLevel(f.Severity),
+				Message: sarifMessage{Text: f.Summary},
+			})
+		}
+		doc.Runs = append(doc.Runs, run)
+	}
+	return doc
+}
+
+// sarifLevel maps a scanner's CVSS-derived severity string to a SARIF
+// result level.
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,174 @@
+// Package clair adapts a Clair v4 API as an image-inspector scanner. It
+// posts the scanned layer's manifest to Clair's indexer, polls for the
+// index report, then fetches the matcher's vulnerability report.
+package clair
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+	"github.com/openshift/image-inspector/pkg/scanner"
+)
+
+const scannerName = "clair"
+
+// indexPollInterval is how often Scan re-polls Clair's index report
+// while it waits for indexing (which happens asynchronously) to finish.
+const indexPollInterval = 500 * time.Millisecond
+
+func init() {
+	scanner.Register(&Scanner{Client: http.DefaultClient})
+}
+
+// Scanner talks to a Clair v4 API server.
+type Scanner struct {
+	// APIAddr is the base URL of the Clair v4 API, set via
+	// --scanner-opt clair.api-addr=http://clair:6060.
+	APIAddr string
+	Client  *http.Client
+}
+
+// Name implements scanner.Scanner.
+func (s *Scanner) Name() string {
+	return scannerName
+}
+
+// SetOption implements scanner.OptionSetter.
+func (s *Scanner) SetOption(key, value string) error {
+	switch key {
+	case "api-addr":
+		s.APIAddr = value
+	default:
+		return fmt.Errorf("unknown clair option %q", key)
+	}
+	return nil
+}
+
+// ValidateOptions implements scanner.Scanner.
+func (s *Scanner) ValidateOptions() error {
+	if len(s.APIAddr) == 0 {
+		return fmt.Errorf("clair.api-addr must be set to use the clair scanner")
+	}
+	return nil
+}
+
+// manifest is the subset of Clair's index request body that image-inspector
+// is able to populate from a locally-unpacked rootfs.
+type manifest struct {
+	Hash   string   `json:"hash"`
+	Layers []string `json:"layers"`
+}
+
+// awaitIndexReport polls Clair's index report for hash until indexing
+// finishes, since Clair v4 indexes a manifest asynchronously: the POST
+// to /indexer/api/v1/index_report only enqueues the work, and the
+// matcher's vulnerability report is empty or stale until indexing
+// actually completes.
+func (s *Scanner) awaitIndexReport(ctx context.Context, hash string) error {
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIAddr+"/indexer/api/v1/index_report/"+hash, nil)
+		if err != nil {
+			return fmt.Errorf("unable to build clair index report request: %v", err)
+		}
+		resp, err := s.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("clair index report request failed: %v", err)
+		}
+		var report struct {
+			State string `json:"state"`
+			Err   string `json:"err"`
+		}
+		decodeErr := json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return fmt.Errorf("unable to parse clair index report: %v", decodeErr)
+		}
+		if len(report.Err) > 0 {
+			return fmt.Errorf("clair indexing failed: %s", report.Err)
+		}
+		if report.State == "IndexFinished" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(indexPollInterval):
+		}
+	}
+}
+
+// Scan implements scanner.Scanner. It indexes the image's layer
+// manifest with Clair and returns the resulting vulnerability report as
+// a normalized finding list.
+func (s *Scanner) Scan(ctx context.Context, rootfs string, image iiapi.Image) (iiapi.ScanResult, error) {
+	m := manifest{Hash: image.ID, Layers: image.LayerIDs}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to marshal clair manifest: %v", err)
+	}
+
+	indexReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.APIAddr+"/indexer/api/v1/index_report", bytes.NewReader(body))
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to build clair index request: %v", err)
+	}
+	indexReq.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(indexReq)
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("clair index request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return iiapi.ScanResult{}, fmt.Errorf("clair index request returned %s", resp.Status)
+	}
+
+	if err := s.awaitIndexReport(ctx, m.Hash); err != nil {
+		return iiapi.ScanResult{}, err
+	}
+
+	matchReq, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIAddr+"/matcher/api/v1/vulnerability_report/"+m.Hash, nil)
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to build clair vulnerability report request: %v", err)
+	}
+	matchResp, err := s.Client.Do(matchReq)
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("clair vulnerability report request failed: %v", err)
+	}
+	defer matchResp.Body.Close()
+	if matchResp.StatusCode != http.StatusOK {
+		return iiapi.ScanResult{}, fmt.Errorf("clair vulnerability report request returned %s", matchResp.Status)
+	}
+
+	var report struct {
+		Vulnerabilities map[string]struct {
+			Package struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"package"`
+			FixedInVersion string `json:"fixed_in_version"`
+			Severity       string `json:"severity"`
+			Name           string `json:"name"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(matchResp.Body).Decode(&report); err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to parse clair vulnerability report: %v", err)
+	}
+
+	result := iiapi.ScanResult{Name: scannerName}
+	for id, v := range report.Vulnerabilities {
+		result.Findings = append(result.Findings, iiapi.Finding{
+			CVE:      id,
+			Package:  v.Package.Name,
+			Version:  v.Package.Version,
+			FixedIn:  v.FixedInVersion,
+			Severity: v.Severity,
+			Summary:  v.Name,
+		})
+	}
+	return result, nil
+}
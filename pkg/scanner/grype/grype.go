@@ -0,0 +1,103 @@
+// Package grype adapts anchore/grype as an image-inspector scanner.
+package grype
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+	"github.com/openshift/image-inspector/pkg/scanner"
+)
+
+const scannerName = "grype"
+
+func init() {
+	scanner.Register(&Scanner{})
+}
+
+// Scanner runs the grype CLI against a scanned rootfs.
+type Scanner struct {
+	// DBPath overrides grype's default vulnerability DB location, set
+	// via --scanner-opt grype.db-path=...
+	DBPath string
+}
+
+// Name implements scanner.Scanner.
+func (s *Scanner) Name() string {
+	return scannerName
+}
+
+// SetOption implements scanner.OptionSetter.
+func (s *Scanner) SetOption(key, value string) error {
+	switch key {
+	case "db-path":
+		s.DBPath = value
+	default:
+		return fmt.Errorf("unknown grype option %q", key)
+	}
+	return nil
+}
+
+// ValidateOptions implements scanner.Scanner.
+func (s *Scanner) ValidateOptions() error {
+	if _, err := exec.LookPath("grype"); err != nil {
+		return fmt.Errorf("grype binary not found in PATH: %v", err)
+	}
+	return nil
+}
+
+// Scan implements scanner.Scanner.
+func (s *Scanner) Scan(ctx context.Context, rootfs string, image iiapi.Image) (iiapi.ScanResult, error) {
+	args := []string{"dir:" + rootfs, "-o", "json"}
+	cmd := exec.CommandContext(ctx, "grype", args...)
+	if len(s.DBPath) > 0 {
+		// cmd.Env starts nil, which means "inherit the parent environment";
+		// appending to it directly would instead replace the whole
+		// environment with just this one variable, dropping PATH/HOME/etc.
+		// for the grype subprocess.
+		cmd.Env = append(os.Environ(), "GRYPE_DB_CACHE_DIR="+s.DBPath)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("grype scan of %s failed: %v", rootfs, err)
+	}
+
+	var r struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+				Fix      struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to parse grype output: %v", err)
+	}
+
+	result := iiapi.ScanResult{Name: scannerName}
+	for _, m := range r.Matches {
+		fixedIn := ""
+		if len(m.Vulnerability.Fix.Versions) > 0 {
+			fixedIn = m.Vulnerability.Fix.Versions[0]
+		}
+		result.Findings = append(result.Findings, iiapi.Finding{
+			CVE:      m.Vulnerability.ID,
+			Package:  m.Artifact.Name,
+			Version:  m.Artifact.Version,
+			FixedIn:  fixedIn,
+			Severity: m.Vulnerability.Severity,
+		})
+	}
+	return result, nil
+}
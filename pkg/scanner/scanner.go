@@ -0,0 +1,102 @@
+// Package scanner defines the pluggable scanner registry used by
+// image-inspector. Scan backends (third-party tools such as trivy, grype
+// and clair) register themselves here instead of being hard-coded into
+// ImageInspectorOptions.Validate, so adding a new scanner does not
+// require a new top-level flag. The openscap and clamav scan types are
+// not registered here; ImageInspectorOptions handles them separately.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+)
+
+// Scanner is implemented by every scan backend known to image-inspector.
+// Built-in adapters live in pkg/scanner/trivy, pkg/scanner/grype and
+// pkg/scanner/clair.
+type Scanner interface {
+	// Name is the value used on the --scan-type flag to select this
+	// scanner, e.g. "trivy".
+	Name() string
+	// ValidateOptions validates any scanner-specific options that were
+	// set through SetOption before a scan is attempted.
+	ValidateOptions() error
+	// Scan scans rootfs, the unpacked filesystem of image, and returns a
+	// normalized finding list. Scan must abort and return an error once
+	// ctx is done, so a client disconnect or request timeout stops the
+	// underlying scan process/request rather than leaking it.
+	Scan(ctx context.Context, rootfs string, image iiapi.Image) (iiapi.ScanResult, error)
+}
+
+// OptionSetter is implemented by scanners that accept namespaced
+// --scanner-opt values, e.g. "trivy.db-path=/var/lib/trivy".
+type OptionSetter interface {
+	// SetOption sets a single scanner-specific option named key to value.
+	SetOption(key, value string) error
+}
+
+var registry = map[string]Scanner{}
+
+// Register adds s to the set of known scanners under s.Name(). Scanner
+// packages are expected to call Register from their init() function.
+func Register(s Scanner) {
+	registry[s.Name()] = s
+}
+
+// Get returns the scanner registered under name, if any.
+func Get(name string) (Scanner, bool) {
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Names returns the scan-type names of all registered scanners.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ParseOpt splits a "namespace.key=value" --scanner-opt value, as in
+// "trivy.db-path=/var/lib/trivy", into the scanner namespace, option key
+// and value.
+func ParseOpt(raw string) (namespace, key, value string, err error) {
+	namespaced, value, ok := strings.Cut(raw, "=")
+	if !ok {
+		return "", "", "", fmt.Errorf("scanner-opt %q must be in namespace.key=value form", raw)
+	}
+	namespace, key, ok = strings.Cut(namespaced, ".")
+	if !ok {
+		return "", "", "", fmt.Errorf("scanner-opt %q must be in namespace.key=value form", raw)
+	}
+	return namespace, key, value, nil
+}
+
+// ApplyOpts parses each raw --scanner-opt value and applies it to the
+// registered scanner whose name matches the option's namespace. It
+// returns an error if a namespace does not match a registered scanner or
+// that scanner does not accept options.
+func ApplyOpts(raw []string) error {
+	for _, o := range raw {
+		namespace, key, value, err := ParseOpt(o)
+		if err != nil {
+			return err
+		}
+		s, ok := Get(namespace)
+		if !ok {
+			return fmt.Errorf("scanner-opt %q refers to unknown scanner %q", o, namespace)
+		}
+		setter, ok := s.(OptionSetter)
+		if !ok {
+			return fmt.Errorf("scanner %q does not accept scanner-opt values", namespace)
+		}
+		if err := setter.SetOption(key, value); err != nil {
+			return fmt.Errorf("scanner-opt %q: %v", o, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+)
+
+// report mirrors the subset of trivy's JSON output format that is needed
+// to populate an iiapi.ScanResult.
+type report struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+func parseResult(out []byte) (iiapi.ScanResult, error) {
+	var r report
+	if err := json.Unmarshal(out, &r); err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("unable to parse trivy output: %v", err)
+	}
+
+	result := iiapi.ScanResult{Name: scannerName}
+	for _, target := range r.Results {
+		for _, v := range target.Vulnerabilities {
+			result.Findings = append(result.Findings, iiapi.Finding{
+				CVE:      v.VulnerabilityID,
+				Package:  v.PkgName,
+				Version:  v.InstalledVersion,
+				FixedIn:  v.FixedVersion,
+				Severity: v.Severity,
+				Summary:  v.Title,
+			})
+		}
+	}
+	return result, nil
+}
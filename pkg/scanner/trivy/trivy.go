@@ -0,0 +1,65 @@
+// Package trivy adapts aquasecurity/trivy as an image-inspector scanner,
+// giving access to its bundled vulnerability DB (OS packages and
+// language dependencies) beyond the RHSA-only coverage of openscap.
+package trivy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+	"github.com/openshift/image-inspector/pkg/scanner"
+)
+
+const scannerName = "trivy"
+
+func init() {
+	scanner.Register(&Scanner{})
+}
+
+// Scanner runs the trivy CLI against a scanned rootfs.
+type Scanner struct {
+	// DBPath overrides trivy's default vulnerability DB location, set
+	// via --scanner-opt trivy.db-path=...
+	DBPath string
+}
+
+// Name implements scanner.Scanner.
+func (s *Scanner) Name() string {
+	return scannerName
+}
+
+// SetOption implements scanner.OptionSetter.
+func (s *Scanner) SetOption(key, value string) error {
+	switch key {
+	case "db-path":
+		s.DBPath = value
+	default:
+		return fmt.Errorf("unknown trivy option %q", key)
+	}
+	return nil
+}
+
+// ValidateOptions implements scanner.Scanner.
+func (s *Scanner) ValidateOptions() error {
+	if _, err := exec.LookPath("trivy"); err != nil {
+		return fmt.Errorf("trivy binary not found in PATH: %v", err)
+	}
+	return nil
+}
+
+// Scan implements scanner.Scanner.
+func (s *Scanner) Scan(ctx context.Context, rootfs string, image iiapi.Image) (iiapi.ScanResult, error) {
+	args := []string{"rootfs", "--format", "json"}
+	if len(s.DBPath) > 0 {
+		args = append(args, "--cache-dir", s.DBPath)
+	}
+	args = append(args, rootfs)
+
+	out, err := exec.CommandContext(ctx, "trivy", args...).Output()
+	if err != nil {
+		return iiapi.ScanResult{}, fmt.Errorf("trivy scan of %s failed: %v", rootfs, err)
+	}
+	return parseResult(out)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// cancelableTransport wraps an http.RoundTripper with a CancelRequest
+// method, mirroring the legacy k8s client-go wrapped-transport pattern:
+// callers that only know how to cancel via CancelRequest (rather than a
+// request context) can still abort an in-flight POST to PostResultURL.
+type cancelableTransport struct {
+	wrapped http.RoundTripper
+
+	mu      sync.Mutex
+	cancels map[*http.Request]context.CancelFunc
+}
+
+// NewPostResultTransport returns the RoundTripper used by the HTTP client
+// that posts scan results to ImageInspectorOptions.PostResultURL.
+func NewPostResultTransport(wrapped http.RoundTripper) http.RoundTripper {
+	if wrapped == nil {
+		wrapped = http.DefaultTransport
+	}
+	return &cancelableTransport{wrapped: wrapped, cancels: map[*http.Request]context.CancelFunc{}}
+}
+
+// RoundTrip implements http.RoundTripper. It derives a cancelable context
+// from req, keyed by req itself so CancelRequest can find it later, and
+// makes sure the request is aborted either via that context being
+// cancelled or CancelRequest being called while it is in flight.
+func (t *cancelableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+
+	t.mu.Lock()
+	t.cancels[req] = cancel
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.cancels, req)
+		t.mu.Unlock()
+		cancel()
+	}()
+
+	return t.wrapped.RoundTrip(req.WithContext(ctx))
+}
+
+// CancelRequest implements the legacy k8s client-go CancelRequest
+// interface: callers that hold a reference to req but not its context
+// can still abort it mid-flight.
+func (t *cancelableTransport) CancelRequest(req *http.Request) {
+	t.mu.Lock()
+	cancel, ok := t.cancels[req]
+	t.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// NewPostResultClient returns the http.Client ImageInspectorOptions.
+// PostResults uses to POST scan results to PostResultURL, wrapped with
+// cancelableTransport so a client disconnect or context cancellation
+// aborts the in-flight POST.
+func NewPostResultClient() *http.Client {
+	return &http.Client{Transport: NewPostResultTransport(nil)}
+}
@@ -0,0 +1,237 @@
+// Package server implements the HTTP API that lets a client trigger and
+// observe a scan without re-launching the image-inspector binary: a
+// JSON-RPC-style POST to start a scan and a long-poll/SSE endpoint to
+// stream its progress, both of which propagate client disconnects and
+// timeouts into the running scanner via context.Context.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	iiapi "github.com/openshift/image-inspector/pkg/api"
+	"github.com/openshift/image-inspector/pkg/scanner"
+)
+
+// ScanRequest is the body of a POST /api/v2/scan request.
+type ScanRequest struct {
+	Image      string            `json:"image"`
+	ScanTypes  []string          `json:"scanTypes"`
+	PullPolicy string            `json:"pullPolicy"`
+	Auth       map[string]string `json:"auth"`
+}
+
+// ScanUpdate is one incremental update delivered over
+// GET /api/v2/scan/stream, either a partial per-scanner result or a
+// terminal error.
+type ScanUpdate struct {
+	Scanner string            `json:"scanner"`
+	Result  *iiapi.ScanResult `json:"result,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Done    bool              `json:"done"`
+}
+
+// scanRun tracks one in-flight scan started via POST /api/v2/scan, so
+// GET /api/v2/scan/stream can attach to it and replay/stream its
+// updates.
+type scanRun struct {
+	mu        sync.Mutex
+	updates   []ScanUpdate
+	subs      map[int]chan ScanUpdate
+	nextSubID int
+	cancel    context.CancelFunc
+}
+
+func (r *scanRun) publish(u ScanUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, u)
+	for _, ch := range r.subs {
+		// Never block the publisher on a slow or abandoned subscriber: a
+		// full channel just misses this update rather than wedging every
+		// future publish/subscribe call for the run.
+		select {
+		case ch <- u:
+		default:
+		}
+	}
+}
+
+func (r *scanRun) subscribe() (int, <-chan ScanUpdate, []ScanUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch := make(chan ScanUpdate, len(r.updates)+8)
+	id := r.nextSubID
+	r.nextSubID++
+	if r.subs == nil {
+		r.subs = map[int]chan ScanUpdate{}
+	}
+	r.subs[id] = ch
+	return id, ch, append([]ScanUpdate{}, r.updates...)
+}
+
+// unsubscribe removes id's channel and, if it was the last remaining
+// subscriber, cancels the run. The run's context is shared by every
+// concurrent viewer of GET /api/v2/scan/stream?id=..., so a single
+// viewer disconnecting must not cancel the scan out from under the
+// others still attached; only the last one leaving should.
+func (r *scanRun) unsubscribe(id int) {
+	r.mu.Lock()
+	delete(r.subs, id)
+	last := len(r.subs) == 0
+	r.mu.Unlock()
+	if last {
+		r.cancel()
+	}
+}
+
+// Handler serves the scan trigger and streaming endpoints. Rootfs
+// resolves the unpacked rootfs path for an image once it has been pulled
+// (pull itself is outside Handler's scope; it is wired up by the caller).
+type Handler struct {
+	Rootfs func(ctx context.Context, req ScanRequest) (string, iiapi.Image, error)
+	// ScanTimeout bounds how long a single scanner may run before its
+	// context is cancelled, e.g. to match a 30s client timeout.
+	ScanTimeout time.Duration
+	// RunTTL bounds how long a finished run's updates are kept around for
+	// a late-attaching GET /api/v2/scan/stream before being evicted, so
+	// h.runs doesn't grow without bound across the process's lifetime.
+	// Zero means defaultRunTTL.
+	RunTTL time.Duration
+
+	mu   sync.Mutex
+	runs map[string]*scanRun
+}
+
+// defaultRunTTL is how long a completed run is kept in h.runs when
+// Handler.RunTTL is unset.
+const defaultRunTTL = 5 * time.Minute
+
+// NewHandler returns a Handler ready to be mounted under /api/v2/scan.
+func NewHandler(rootfs func(ctx context.Context, req ScanRequest) (string, iiapi.Image, error), scanTimeout time.Duration) *Handler {
+	return &Handler{Rootfs: rootfs, ScanTimeout: scanTimeout, runs: map[string]*scanRun{}}
+}
+
+func (h *Handler) evictRun(id string) {
+	ttl := h.RunTTL
+	if ttl <= 0 {
+		ttl = defaultRunTTL
+	}
+	time.AfterFunc(ttl, func() {
+		h.mu.Lock()
+		delete(h.runs, id)
+		h.mu.Unlock()
+	})
+}
+
+// StartScan implements POST /api/v2/scan: it decodes req, launches each
+// requested scanner in the background, and returns an id that
+// GET /api/v2/scan/stream?id=... can be used to observe.
+func (h *Handler) StartScan(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid scan request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if h.ScanTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.ScanTimeout)
+	}
+	run := &scanRun{cancel: cancel}
+
+	id := fmt.Sprintf("%p", run)
+	h.mu.Lock()
+	h.runs[id] = run
+	h.mu.Unlock()
+
+	go h.drive(ctx, id, run, req)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+func (h *Handler) drive(ctx context.Context, id string, run *scanRun, req ScanRequest) {
+	defer h.evictRun(id)
+
+	rootfs, image, err := h.Rootfs(ctx, req)
+	if err != nil {
+		run.publish(ScanUpdate{Error: err.Error(), Done: true})
+		return
+	}
+
+	for _, st := range req.ScanTypes {
+		s, ok := scanner.Get(st)
+		if !ok {
+			run.publish(ScanUpdate{Scanner: st, Error: fmt.Sprintf("unknown scan-type %q", st), Done: true})
+			continue
+		}
+		result, err := s.Scan(ctx, rootfs, image)
+		if err != nil {
+			run.publish(ScanUpdate{Scanner: st, Error: err.Error(), Done: true})
+			continue
+		}
+		run.publish(ScanUpdate{Scanner: st, Result: &result, Done: true})
+	}
+}
+
+// StreamScan implements GET /api/v2/scan/stream: it replays any updates
+// already produced for the run named by the "id" query parameter as
+// server-sent events, then streams new ones until the client
+// disconnects. The stream is not ended when the last scanner finishes;
+// each scanner's update carries Done set to true.
+func (h *Handler) StreamScan(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	h.mu.Lock()
+	run, ok := h.runs[id]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown scan id %q", id), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	subID, ch, backlog := run.subscribe()
+	defer run.unsubscribe(subID)
+	for _, u := range backlog {
+		writeEvent(w, u)
+	}
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			// The client disconnected or the request timed out; the
+			// deferred unsubscribe above cancels the run only once this
+			// was its last remaining viewer, so other viewers still
+			// attached to the same run aren't cut off.
+			return
+		case u, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeEvent(w, u)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, u ScanUpdate) {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", b)
+}
@@ -0,0 +1,143 @@
+// Package transport resolves and pulls images using the containers/image
+// and containers/storage libraries, the same stack used by
+// Podman/Buildah/Skopeo. It lets image-inspector unpack an image without
+// a Docker daemon: from a registry, an OCI layout on disk, or local
+// containers/storage, depending on the selected transport.
+package transport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/directory"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/storage"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+)
+
+// Names of the transports accepted by the --transport flag, matching the
+// containers/image transport scheme names.
+const (
+	Docker            = "docker"
+	DockerDaemon      = "docker-daemon"
+	Dir               = "dir"
+	OCI               = "oci"
+	ContainersStorage = "containers-storage"
+)
+
+// Names lists every transport image-inspector knows how to pull from.
+var Names = []string{Docker, DockerDaemon, Dir, OCI, ContainersStorage}
+
+// RequiresURI reports whether the named transport needs
+// ImageInspectorOptions.URI (a Docker daemon socket) to operate. Only the
+// legacy docker-daemon transport does; every containers/image-backed
+// transport resolves the image reference on its own.
+func RequiresURI(name string) bool {
+	return name == DockerDaemon || name == ""
+}
+
+// ParseReference resolves imageName under the named transport into a
+// types.ImageReference, as skopeo does for its source argument.
+func ParseReference(name, imageName string) (types.ImageReference, error) {
+	switch name {
+	case Docker:
+		return docker.ParseReference("//" + imageName)
+	case Dir:
+		return directory.NewReference(imageName)
+	case OCI:
+		return layout.ParseReference(imageName)
+	case ContainersStorage:
+		return storage.Transport.ParseReference(imageName)
+	default:
+		return alltransports.ParseImageName(fmt.Sprintf("%s:%s", name, imageName))
+	}
+}
+
+// Pull copies the image identified by ref into dstPath using
+// containers/image, without requiring a running Docker daemon, then
+// unpacks its layers so dstPath ends up holding the image's actual
+// filesystem rather than the dir: transport's raw blobs. sys carries the
+// per-registry credentials (see pkg/auth) to pull ref's layers with, and
+// may be nil to pull anonymously.
+func Pull(ctx context.Context, ref types.ImageReference, dstPath string, sys *types.SystemContext) error {
+	if sys == nil {
+		sys = &types.SystemContext{}
+	}
+
+	stageDir, err := os.MkdirTemp("", "image-inspector-pull-")
+	if err != nil {
+		return fmt.Errorf("unable to create staging dir: %v", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	destRef, err := directory.NewReference(stageDir)
+	if err != nil {
+		return fmt.Errorf("unable to resolve staging dir %s: %v", stageDir, err)
+	}
+
+	policy, err := signature.DefaultPolicy(sys)
+	if err != nil {
+		return fmt.Errorf("unable to load signature policy: %v", err)
+	}
+	policyCtx, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return fmt.Errorf("unable to create policy context: %v", err)
+	}
+	defer policyCtx.Destroy()
+
+	if _, err := copy.Image(ctx, policyCtx, destRef, ref, &copy.Options{SourceCtx: sys}); err != nil {
+		return fmt.Errorf("unable to pull image: %v", err)
+	}
+
+	return unpack(stageDir, dstPath)
+}
+
+// unpack applies the layers described by stageDir's manifest.json (as
+// written by the dir: transport) onto dstPath in order, so the result
+// is the image's real merged rootfs rather than a folder of opaque
+// blob/manifest files.
+func unpack(stageDir, dstPath string) error {
+	manifestBytes, err := os.ReadFile(filepath.Join(stageDir, "manifest.json"))
+	if err != nil {
+		return fmt.Errorf("unable to read staged manifest: %v", err)
+	}
+	mimeType := manifest.GuessMIMEType(manifestBytes)
+	man, err := manifest.FromBlob(manifestBytes, mimeType)
+	if err != nil {
+		return fmt.Errorf("unable to parse staged manifest: %v", err)
+	}
+
+	if err := os.MkdirAll(dstPath, 0755); err != nil {
+		return fmt.Errorf("unable to create destination %s: %v", dstPath, err)
+	}
+
+	for _, layer := range man.LayerInfos() {
+		if layer.EmptyLayer {
+			continue
+		}
+		if err := applyLayerFile(stageDir, dstPath, layer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyLayerFile(stageDir, dstPath string, layer manifest.LayerInfo) error {
+	f, err := os.Open(filepath.Join(stageDir, layer.Digest.Encoded()))
+	if err != nil {
+		return fmt.Errorf("unable to open staged layer %s: %v", layer.Digest, err)
+	}
+	defer f.Close()
+
+	if err := applyLayer(dstPath, f, layer.MediaType); err != nil {
+		return fmt.Errorf("unable to apply layer %s: %v", layer.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package transport
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// applyLayer extracts a single layer tar (optionally gzip-compressed,
+// per mediaType) onto dst, honoring AUFS-style whiteouts the way an OCI
+// runtime does when unpacking layers: a ".wh.<name>" entry deletes
+// <name> from the layers applied so far, and a ".wh..wh..opq" entry
+// empties the directory it appears in before lower entries are added.
+//
+// dst is meant to hold an untrusted remote registry image's filesystem,
+// so every entry's name, and every symlink/hardlink target, is resolved
+// and confirmed to stay within dst before being applied; entries that
+// would escape it (tar-slip/zip-slip) are rejected.
+func applyLayer(dst string, r io.Reader, mediaType string) error {
+	var tr *tar.Reader
+	if strings.Contains(mediaType, "gzip") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("unable to open gzip layer: %v", err)
+		}
+		defer gz.Close()
+		tr = tar.NewReader(gz)
+	} else {
+		tr = tar.NewReader(r)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read layer tar: %v", err)
+		}
+
+		name := filepath.Clean(hdr.Name)
+		dir, base := filepath.Split(name)
+
+		if base == opaqueWhiteout {
+			target, err := safeJoin(dst, dir)
+			if err != nil {
+				return err
+			}
+			if err := clearDir(target); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			target, err := safeJoin(dst, filepath.Join(dir, base[len(whiteoutPrefix):]))
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := applyEntry(dst, name, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// safeJoin resolves name against dst and confirms the result does not
+// escape dst, rejecting tar entries like "../../etc/cron.d/evil" or
+// absolute paths that would otherwise resolve outside dst.
+func safeJoin(dst, name string) (string, error) {
+	target := filepath.Join(dst, name)
+	root := filepath.Clean(dst)
+	if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("tar entry %q escapes destination %s", name, dst)
+	}
+	return target, nil
+}
+
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyEntry(dst, name string, hdr *tar.Header, tr *tar.Reader) error {
+	target, err := safeJoin(dst, name)
+	if err != nil {
+		return err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, os.FileMode(hdr.Mode))
+	case tar.TypeSymlink:
+		if !symlinkStaysWithinRoot(dst, target, hdr.Linkname) {
+			return fmt.Errorf("tar entry %q: symlink target %q escapes destination %s", name, hdr.Linkname, dst)
+		}
+		os.Remove(target)
+		return os.Symlink(hdr.Linkname, target)
+	case tar.TypeLink:
+		linkTarget, err := safeJoin(dst, hdr.Linkname)
+		if err != nil {
+			return fmt.Errorf("tar entry %q: hardlink target %q escapes destination %s", name, hdr.Linkname, dst)
+		}
+		os.Remove(target)
+		return os.Link(linkTarget, target)
+	default:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, tr)
+		return err
+	}
+}
+
+// symlinkStaysWithinRoot reports whether a symlink at target (already
+// confirmed to be within dst) pointing to linkname -- absolute, or
+// relative to the symlink's own directory -- resolves to a path still
+// within dst. It does not require the link's target to already exist.
+func symlinkStaysWithinRoot(dst, target, linkname string) bool {
+	var resolved string
+	if filepath.IsAbs(linkname) {
+		resolved = filepath.Join(dst, linkname)
+	} else {
+		resolved = filepath.Join(filepath.Dir(target), linkname)
+	}
+	resolved = filepath.Clean(resolved)
+	root := filepath.Clean(dst)
+	return resolved == root || strings.HasPrefix(resolved, root+string(filepath.Separator))
+}
@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func buildTar(t *testing.T, entries []tar.Header, bodies map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range entries {
+		h := hdr
+		body := bodies[hdr.Name]
+		h.Size = int64(len(body))
+		if err := tw.WriteHeader(&h); err != nil {
+			t.Fatalf("WriteHeader(%s): %v", hdr.Name, err)
+		}
+		if len(body) > 0 {
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatalf("Write(%s): %v", hdr.Name, err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close: %v", err)
+	}
+	return &buf
+}
+
+func TestApplyLayerExtractsRegularFiles(t *testing.T) {
+	dst := t.TempDir()
+	buf := buildTar(t, []tar.Header{
+		{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "etc/hostname", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"etc/hostname": "box\n"})
+
+	if err := applyLayer(dst, buf, "application/vnd.oci.image.layer.v1.tar"); err != nil {
+		t.Fatalf("applyLayer: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "etc/hostname"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "box\n" {
+		t.Fatalf("got %q, want %q", got, "box\n")
+	}
+}
+
+func TestApplyLayerRejectsPathTraversal(t *testing.T) {
+	dst := t.TempDir()
+	buf := buildTar(t, []tar.Header{
+		{Name: "../../etc/cron.d/evil", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"../../etc/cron.d/evil": "* * * * * root touch /tmp/pwned\n"})
+
+	if err := applyLayer(dst, buf, "application/vnd.oci.image.layer.v1.tar"); err == nil {
+		t.Fatalf("expected applyLayer to reject a path-traversal entry, got nil error")
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(dst)), "etc/cron.d/evil")); err == nil {
+		t.Fatalf("path-traversal entry escaped dst and was written to disk")
+	}
+}
+
+func TestApplyLayerConfinesAbsolutePathToDst(t *testing.T) {
+	dst := t.TempDir()
+	buf := buildTar(t, []tar.Header{
+		{Name: "/etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"/etc/passwd": "root:x:0:0\n"})
+
+	// An absolute-looking tar name is still just a name relative to dst
+	// once safely joined, so this should land inside dst, not escape it.
+	if err := applyLayer(dst, buf, ""); err != nil {
+		t.Fatalf("applyLayer: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "etc/passwd")); err != nil {
+		t.Fatalf("expected entry to be written inside dst: %v", err)
+	}
+}
+
+func TestApplyLayerRejectsSymlinkEscape(t *testing.T) {
+	dst := t.TempDir()
+	buf := buildTar(t, []tar.Header{
+		{Name: "evil-link", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd", Mode: 0777},
+	}, nil)
+
+	if err := applyLayer(dst, buf, ""); err == nil {
+		t.Fatalf("expected applyLayer to reject a symlink escaping dst, got nil error")
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "evil-link")); err == nil {
+		t.Fatalf("escaping symlink should not have been created")
+	}
+}
+
+func TestApplyLayerAllowsSymlinkWithinRoot(t *testing.T) {
+	dst := t.TempDir()
+	buf := buildTar(t, []tar.Header{
+		{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "etc/real", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "etc/alias", Typeflag: tar.TypeSymlink, Linkname: "real", Mode: 0777},
+	}, map[string]string{"etc/real": "hi\n"})
+
+	if err := applyLayer(dst, buf, ""); err != nil {
+		t.Fatalf("applyLayer: %v", err)
+	}
+	target, err := os.Readlink(filepath.Join(dst, "etc/alias"))
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+	if target != "real" {
+		t.Fatalf("got symlink target %q, want %q", target, "real")
+	}
+}
+
+func TestApplyLayerWhiteoutRemovesEntry(t *testing.T) {
+	dst := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dst, "var"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "var/gone"), []byte("x"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	buf := buildTar(t, []tar.Header{
+		{Name: "var/.wh.gone", Typeflag: tar.TypeReg, Mode: 0644},
+	}, nil)
+	if err := applyLayer(dst, buf, ""); err != nil {
+		t.Fatalf("applyLayer: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "var/gone")); !os.IsNotExist(err) {
+		t.Fatalf("expected var/gone to be removed by whiteout, stat err = %v", err)
+	}
+}
+
+func TestApplyLayerOpaqueWhiteoutClearsDir(t *testing.T) {
+	dst := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dst, "var/lib"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "var/lib/stale"), []byte("x"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	buf := buildTar(t, []tar.Header{
+		{Name: "var/.wh..wh..opq", Typeflag: tar.TypeReg, Mode: 0644},
+	}, nil)
+	if err := applyLayer(dst, buf, ""); err != nil {
+		t.Fatalf("applyLayer: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dst, "var"))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected var/ to be emptied by opaque whiteout, got %v", entries)
+	}
+}
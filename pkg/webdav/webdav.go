@@ -0,0 +1,114 @@
+// Package webdav serves an unpacked image rootfs over WebDAV, with
+// optional HTTP basic auth, TLS, and a URL prefix for mounting under a
+// larger API surface.
+package webdav
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/webdav"
+
+	"github.com/openshift/image-inspector/pkg/cmd"
+)
+
+// Handler builds the net/http.Handler that serves root over WebDAV
+// according to opts, wrapping it with basic-auth (or the existing
+// AuthToken as a bearer alternative). When ServeReadOnly is set,
+// unauthenticated PROPFIND/OPTIONS/GET requests are allowed so
+// read-only mounts by scanners work without credentials, while every
+// other method still needs them.
+func Handler(root string, opts *cmd.ImageInspectorOptions) (http.Handler, error) {
+	h := &webdav.Handler{
+		FileSystem: webdav.Dir(root),
+		LockSystem: webdav.NewMemLS(),
+		Prefix:     opts.ServePrefix,
+	}
+
+	var creds map[string]string
+	if len(opts.ServeAuthBasicFile) > 0 {
+		var err error
+		creds, err = parseHtpasswd(opts.ServeAuthBasicFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read serve-auth-basic-file: %v", err)
+		}
+	}
+
+	return &authHandler{
+		wrapped:   h,
+		creds:     creds,
+		authToken: opts.AuthToken,
+		readOnly:  opts.ServeReadOnly,
+	}, nil
+}
+
+// TLSConfig builds the *tls.Config used to serve WebDAV over HTTPS from
+// opts.ServeTLSCert/opts.ServeTLSKey, or nil if TLS was not requested.
+func TLSConfig(opts *cmd.ImageInspectorOptions) (*tls.Config, error) {
+	if len(opts.ServeTLSCert) == 0 && len(opts.ServeTLSKey) == 0 {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(opts.ServeTLSCert, opts.ServeTLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load serve-tls-cert/serve-tls-key: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+// unauthenticatedReadMethods are allowed without credentials when
+// ServeReadOnly is set, so read-only scanner mounts work out of the box.
+var unauthenticatedReadMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodOptions: true,
+	"PROPFIND":         true,
+}
+
+type authHandler struct {
+	wrapped   http.Handler
+	creds     map[string]string
+	authToken string
+	readOnly  bool
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly && unauthenticatedReadMethods[r.Method] {
+		h.wrapped.ServeHTTP(w, r)
+		return
+	}
+	if !h.authRequired() && !h.readOnly {
+		h.wrapped.ServeHTTP(w, r)
+		return
+	}
+	if h.authorized(r) {
+		h.wrapped.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="image-inspector"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// authRequired reports whether any of ServeAuthBasicFile/AuthToken were
+// configured. When neither is set, --serve must keep behaving the way it
+// did before those flags existed: serving unauthenticated, not denying
+// every request.
+func (h *authHandler) authRequired() bool {
+	return len(h.authToken) > 0 || h.creds != nil
+}
+
+func (h *authHandler) authorized(r *http.Request) bool {
+	if len(h.authToken) > 0 && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.authToken)) == 1 {
+		return true
+	}
+	if h.creds == nil {
+		return false
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	want, ok := h.creds[user]
+	return ok && bcrypt.CompareHashAndPassword([]byte(want), []byte(pass)) == nil
+}